@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/erikdubbelboer/fasthttp"
+	"github.com/nethruster/linksh/models"
+)
+
+//currentUser resolves the user performing the request from its session cookie or API key.
+//It returns an error if the caller could not be authenticated
+func (env Env) currentUser(ctx *fasthttp.RequestCtx) (models.User, error) {
+	var user models.User
+
+	if apikey := string(ctx.Request.Header.Peek("X-Api-Key")); apikey != "" {
+		err := env.Db.Where("apikey = ?", apikey).Take(&user).Error
+		return user, err
+	}
+
+	sessionID := string(ctx.Request.Header.Cookie("linksh_session"))
+	if sessionID == "" || strings.HasPrefix(sessionID, reauthSessionPrefix) {
+		return user, fmt.Errorf("the request is not authenticated")
+	}
+
+	session, err := env.Sessions.Get(ctx, sessionID)
+	if err != nil {
+		return user, err
+	}
+
+	err = env.Db.Where("id = ?", session.OwnerID).Take(&user).Error
+	return user, err
+}
+
+//forbidden writes a 403 response, used by handlers that perform their own inline
+//authorization check (e.g. GetUsers, EditUser, DeleteUser) when the caller doesn't meet it
+func forbidden(ctx *fasthttp.RequestCtx) {
+	ctx.SetContentType("application/json")
+	ctx.Response.Header.SetStatusCode(403)
+	fmt.Fprint(ctx, `{"error": "Forbidden"}`)
+}
@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	gonanoid "github.com/matoous/go-nanoid"
+
+	"github.com/erikdubbelboer/fasthttp"
+	"github.com/nethruster/linksh/models"
+	"github.com/nethruster/linksh/pkg/auth/oauth"
+	"github.com/nethruster/linksh/pkg/models/sessions"
+	"github.com/sirupsen/logrus"
+)
+
+//sessionTTL is how long a session created through an OAuth login stays valid
+const sessionTTL = 30 * 24 * time.Hour
+
+//stateCookieName is the cookie used to round-trip the signed state param between
+//HandleOAuthLogin and HandleOAuthCallback
+const stateCookieName = "linksh_oauth_state"
+
+//HandleOAuthLogin redirects the caller to the selected provider's login page.
+//Wire this to `GET /auth/oauth/{provider}/login`
+func (env Env) HandleOAuthLogin(ctx *fasthttp.RequestCtx) {
+	name := fmt.Sprintf("%v", ctx.UserValue("provider"))
+	ctx.SetContentType("application/json")
+
+	provider, err := env.OAuthProviders.Get(name)
+	if err != nil {
+		ctx.Response.Header.SetStatusCode(404)
+		fmt.Fprint(ctx, `{"error": "Unknown provider"}`)
+		return
+	}
+
+	redirectURL, state, err := provider.HandleLogin()
+	if err != nil {
+		ctx.Response.Header.SetStatusCode(500)
+		fmt.Fprint(ctx, `{"error": "Internal server error"}`)
+		env.Log.WithFields(logrus.Fields{"event": "OAuth login", "status": "Failed"}).Error(err.Error())
+		return
+	}
+
+	cookie := fasthttp.Cookie{}
+	cookie.SetKey(stateCookieName)
+	cookie.SetValue(state)
+	cookie.SetHTTPOnly(true)
+	cookie.SetSecure(true)
+	ctx.Response.Header.SetCookie(&cookie)
+
+	ctx.Redirect(redirectURL, fasthttp.StatusFound)
+}
+
+//HandleOAuthCallback finishes the login flow started by HandleOAuthLogin: it exchanges the
+//authorization code, looks up (or auto-provisions) the matching linksh user and creates a
+//session for it.
+//Wire this to `GET /auth/oauth/{provider}/callback`
+func (env Env) HandleOAuthCallback(ctx *fasthttp.RequestCtx) {
+	name := fmt.Sprintf("%v", ctx.UserValue("provider"))
+	args := ctx.QueryArgs()
+	ctx.SetContentType("application/json")
+
+	provider, err := env.OAuthProviders.Get(name)
+	if err != nil {
+		ctx.Response.Header.SetStatusCode(404)
+		fmt.Fprint(ctx, `{"error": "Unknown provider"}`)
+		return
+	}
+
+	cookieState := string(ctx.Request.Header.Cookie(stateCookieName))
+	queryState := string(args.Peek("state"))
+	code := string(args.Peek("code"))
+
+	//The query state is what the IdP echoes back on the callback URL, the cookie state is what
+	//HandleOAuthLogin planted in the caller's browser. They must match, otherwise an attacker
+	//could plant their own state cookie in a victim's browser (e.g. by having the victim load
+	//the login URL) and then trick the victim into completing the callback with the attacker's
+	//own authorization code, linking the victim's session to the attacker's account
+	if queryState == "" || queryState != cookieState {
+		ctx.Response.Header.SetStatusCode(400)
+		fmt.Fprint(ctx, `{"error": "Invalid or expired login attempt"}`)
+		return
+	}
+
+	info, err := provider.HandleCallback(code, cookieState)
+	if err != nil {
+		ctx.Response.Header.SetStatusCode(400)
+		fmt.Fprint(ctx, `{"error": "Invalid or expired login attempt"}`)
+		env.Log.WithFields(logrus.Fields{"event": "OAuth callback", "status": "Failed"}).Error(err.Error())
+		return
+	}
+
+	var user models.User
+	err = env.Db.Where("oauth_provider = ? AND oauth_subject = ?", name, info.Subject).Take(&user).Error
+	if err != nil && err.Error() == "record not found" {
+		user, err = env.provisionOAuthUser(name, info)
+	}
+	if err != nil {
+		ctx.Response.Header.SetStatusCode(500)
+		fmt.Fprint(ctx, `{"error": "Internal server error"}`)
+		env.Log.WithFields(logrus.Fields{"event": "OAuth callback", "status": "Failed"}).Error(err.Error())
+		return
+	}
+
+	session, err := env.createSessionForUser(ctx, user)
+	if err != nil {
+		ctx.Response.Header.SetStatusCode(500)
+		fmt.Fprint(ctx, `{"error": "Internal server error"}`)
+		env.Log.WithFields(logrus.Fields{"event": "OAuth callback", "status": "Failed"}).Error(err.Error())
+		return
+	}
+
+	env.Log.WithFields(logrus.Fields{"event": "OAuth callback", "status": "successful"}).Info(fmt.Sprintf(`User '%v' logged in through the '%v' provider`, user.Id, name))
+
+	json.NewEncoder(ctx).Encode(&session)
+}
+
+//provisionOAuthUser creates a linksh user for a first-time OAuth login: it gets a random
+//password hash (the user never authenticates with a password) and no API key
+func (env Env) provisionOAuthUser(provider string, info oauth.UserInfo) (models.User, error) {
+	randomPassword, err := models.GenerateUserApiKey()
+	if err != nil {
+		return models.User{}, err
+	}
+
+	passwordHash, err := models.HashPassword([]byte(randomPassword))
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user := models.User{
+		Username:      info.Username,
+		Email:         info.Email,
+		Password:      passwordHash,
+		OAuthProvider: provider,
+		OAuthSubject:  info.Subject,
+	}
+
+	if errs := user.ValidateUser(); errs != nil {
+		return models.User{}, fmt.Errorf("the profile returned by '%v' isn't a valid linksh user: %v", provider, errs[0])
+	}
+
+	if err := user.SaveToDatabase(env.Db); err != nil {
+		return models.User{}, err
+	}
+
+	return user, nil
+}
+
+//createSessionForUser mints and stores a new session for user through the SessionManager
+func (env Env) createSessionForUser(ctx context.Context, user models.User) (sessions.Session, error) {
+	id, err := gonanoid.Nanoid()
+	if err != nil {
+		return sessions.Session{}, err
+	}
+
+	now := time.Now()
+	session := sessions.Session{
+		ID:        id,
+		OwnerID:   user.Id,
+		CreatedAt: now.Unix(),
+		ExpiresOn: now.Add(sessionTTL).Unix(),
+	}
+
+	if err := env.Sessions.Add(ctx, session); err != nil {
+		return sessions.Session{}, err
+	}
+
+	return session, nil
+}
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/erikdubbelboer/fasthttp"
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+//paginate parses the `page`/`page_size` query params off ctx (defaulting to page=1, capping
+//page_size at maxPageSize), counts the total number of rows matched by query and writes the
+//`X-Total-Count` and RFC 5988 `Link` pagination headers to ctx.
+//It returns query with Offset/Limit applied for the requested page
+func paginate(query *gorm.DB, ctx *fasthttp.RequestCtx) *gorm.DB {
+	args := ctx.QueryArgs()
+
+	page, err := strconv.Atoi(string(args.Peek("page")))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(string(args.Peek("page_size")))
+	if err != nil || pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	var total int
+	query.Count(&total)
+
+	lastPage := (total + pageSize - 1) / pageSize
+	if lastPage < 1 {
+		lastPage = 1
+	}
+
+	ctx.Response.Header.Set("X-Total-Count", strconv.Itoa(total))
+	ctx.Response.Header.Set("Link", paginationLinkHeader(ctx, page, pageSize, lastPage))
+
+	return query.Offset((page - 1) * pageSize).Limit(pageSize)
+}
+
+//paginateSub parses `{prefix}_page`/`{prefix}_page_size` query params (same defaults and cap
+//as paginate) and applies Offset/Limit to query for a sub-listing embedded in a bigger
+//response, e.g. the sessions and links embedded in GetUser.
+//It writes the total count to headerName instead of the list-level `X-Total-Count`/`Link`
+//headers, so that embedding more than one paginated sub-listing in a response doesn't have one
+//clobber the other's headers, and each can be paged independently
+func paginateSub(query *gorm.DB, ctx *fasthttp.RequestCtx, prefix, headerName string) *gorm.DB {
+	args := ctx.QueryArgs()
+
+	page, err := strconv.Atoi(string(args.Peek(prefix + "_page")))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(string(args.Peek(prefix + "_page_size")))
+	if err != nil || pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	var total int
+	query.Count(&total)
+
+	ctx.Response.Header.Set(headerName, strconv.Itoa(total))
+
+	return query.Offset((page - 1) * pageSize).Limit(pageSize)
+}
+
+//paginationLinkHeader builds the RFC 5988 `Link` header value for the current page, keeping
+//every other query param (e.g. `email`, `includeSessions`) the caller sent so that following a
+//relation doesn't drop the original filter
+func paginationLinkHeader(ctx *fasthttp.RequestCtx, page, pageSize, lastPage int) string {
+	path := string(ctx.URI().Path())
+
+	link := func(rel string, p int) string {
+		args := fasthttp.AcquireArgs()
+		defer fasthttp.ReleaseArgs(args)
+		ctx.QueryArgs().CopyTo(args)
+		args.Set("page", strconv.Itoa(p))
+		args.Set("page_size", strconv.Itoa(pageSize))
+
+		return fmt.Sprintf(`<%v?%v>; rel="%v"`, path, args.String(), rel)
+	}
+
+	links := []string{link("first", 1)}
+	if page > 1 {
+		links = append(links, link("prev", page-1))
+	}
+	if page < lastPage {
+		links = append(links, link("next", page+1))
+	}
+	links = append(links, link("last", lastPage))
+
+	return strings.Join(links, ", ")
+}
@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	gonanoid "github.com/matoous/go-nanoid"
+
+	"github.com/erikdubbelboer/fasthttp"
+	"github.com/nethruster/linksh/models"
+	"github.com/nethruster/linksh/pkg/models/sessions"
+	"github.com/sirupsen/logrus"
+)
+
+//reauthTokenTTL is how long a reauth token minted by Reauthenticate stays valid
+const reauthTokenTTL = 5 * time.Minute
+
+//reauthSessionPrefix namespaces reauth tokens within the session provider's id space so they
+//can never collide with, or be confused for, a regular login session
+const reauthSessionPrefix = "reauth:"
+
+//ReauthHeader is the header EditUser expects a valid reauth token to be carried in
+const ReauthHeader = "X-Reauth-Token"
+
+//Reauthenticate mints a short-lived, single-use reauth token after the caller re-submits
+//their current password. EditUser requires this token to change a password or regenerate an
+//API key, so that a stolen session cookie alone can't rotate a user's credentials.
+//Wire this to `POST /reauthenticate`
+func (env Env) Reauthenticate(ctx *fasthttp.RequestCtx) {
+	var data map[string]string
+	ctx.SetContentType("application/json")
+
+	caller, err := env.currentUser(ctx)
+	if err != nil {
+		forbidden(ctx)
+		return
+	}
+
+	json.Unmarshal(ctx.Request.Body(), &data)
+
+	if err := models.ComparePassword(caller.Password, []byte(data["password"])); err != nil {
+		ctx.Response.Header.SetStatusCode(400)
+		fmt.Fprint(ctx, `{"error": "Invalid password"}`)
+		return
+	}
+
+	token, err := gonanoid.Nanoid()
+	if err != nil {
+		ctx.Response.Header.SetStatusCode(500)
+		fmt.Fprint(ctx, `{"error": "Internal server error"}`)
+		env.Log.WithFields(logrus.Fields{"event": "Reauthenticate", "status": "Failed"}).Error(err.Error())
+		return
+	}
+
+	now := time.Now()
+	session := sessions.Session{
+		ID:        reauthSessionPrefix + token,
+		OwnerID:   caller.Id,
+		CreatedAt: now.Unix(),
+		ExpiresOn: now.Add(reauthTokenTTL).Unix(),
+	}
+
+	if err := env.Sessions.Add(ctx, session); err != nil {
+		ctx.Response.Header.SetStatusCode(500)
+		fmt.Fprint(ctx, `{"error": "Internal server error"}`)
+		env.Log.WithFields(logrus.Fields{"event": "Reauthenticate", "status": "Failed"}).Error(err.Error())
+		return
+	}
+
+	json.NewEncoder(ctx).Encode(map[string]string{"reauth_token": token})
+}
+
+//consumeReauthToken checks that token was minted for callerID - the authenticated caller who
+//proved their own password, not necessarily the user being edited - hasn't expired and hasn't
+//already been used, then deletes it so it can't be used a second time
+func (env Env) consumeReauthToken(ctx *fasthttp.RequestCtx, callerID, token string) error {
+	if token == "" {
+		return fmt.Errorf("missing reauth token")
+	}
+
+	session, err := env.Sessions.Get(ctx, reauthSessionPrefix+token)
+	if err != nil {
+		return fmt.Errorf("invalid or expired reauth token")
+	}
+
+	if session.OwnerID != callerID || time.Now().Unix() > session.ExpiresOn {
+		return fmt.Errorf("invalid or expired reauth token")
+	}
+
+	return env.Sessions.Delete(ctx, session.ID)
+}
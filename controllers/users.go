@@ -7,25 +7,25 @@ import (
 	"github.com/erikdubbelboer/fasthttp"
 	"strings"
 	"github.com/sirupsen/logrus"
-	"strconv"
 )
 
+//GetUsers lists the users. Only admins may list every user on the instance
 func (env Env) GetUsers(ctx *fasthttp.RequestCtx) {
+	caller, err := env.currentUser(ctx)
+	if err != nil || !caller.IsAdmin {
+		forbidden(ctx)
+		return
+	}
+
 	var users []models.User
 	args := ctx.QueryArgs()
-	query := env.Db
+	query := env.Db.Model(&models.User{})
 
 	if email := string(args.Peek("email")); email != "" {
 		query = query.Where("email like ?", fmt.Sprintf("%%%v%%", email))
 	}
-	if offset, err := strconv.Atoi(string(args.Peek("offset"))); err == nil && offset != 0 {
-		query = query.Offset(offset)
-	}
-	if limit, err := strconv.Atoi(string(args.Peek("limit"))); err == nil && limit != 0 {
-		query = query.Limit(limit)
-	}
 
-	query.Find(&users)
+	paginate(query, ctx).Find(&users)
 
 	ctx.SetContentType("application/json")
 	json.NewEncoder(ctx).Encode(&users)
@@ -53,32 +53,18 @@ func (env Env) GetUser(ctx *fasthttp.RequestCtx) {
 
 	if string(args.Peek("includeSessions")) == "true" {
 		var sessions []models.Session
-		query := env.Db
-
-		if offset, err := strconv.Atoi(string(args.Peek("sessionsOffset"))); err == nil && offset != 0 {
-			query = query.Offset(offset)
-		}
-		if limit, err := strconv.Atoi(string(args.Peek("sessionsLimit"))); err == nil && limit != 0 {
-			query = query.Limit(limit)
-		}
 
-		query.Model(&user).Related(&sessions)
+		query := env.Db.Model(&models.Session{}).Where("user_id = ?", user.Id)
+		paginateSub(query, ctx, "sessions", "X-Sessions-Total-Count").Find(&sessions)
 
 		user.Sessions = sessions
 	}
 
 	if string(args.Peek("includeLinks")) == "true" {
 		var links []models.Link
-		query := env.Db
 
-		if offset, err := strconv.Atoi(string(args.Peek("linksOffset"))); err == nil && offset != 0 {
-			query = query.Offset(offset)
-		}
-		if limit, err := strconv.Atoi(string(args.Peek("linksLimit"))); err == nil && limit != 0 {
-			query = query.Limit(limit)
-		}
-
-		query.Model(&user).Related(&links)
+		query := env.Db.Model(&models.Link{}).Where("user_id = ?", user.Id)
+		paginateSub(query, ctx, "links", "X-Links-Total-Count").Find(&links)
 
 		user.Links = links
 	}
@@ -86,17 +72,27 @@ func (env Env) GetUser(ctx *fasthttp.RequestCtx) {
 	json.NewEncoder(ctx).Encode(&user)
 }
 
+//CreateUser creates a user. Creating an admin user (isAdmin=true) requires the caller to
+//already be an admin; regular user creation is left open to whatever the route is wired to
 func (env Env) CreateUser(ctx *fasthttp.RequestCtx) {
 	var data map[string] string
 	ctx.SetContentType("application/json")
 
 	json.Unmarshal(ctx.Request.Body(), &data)
 
+	if data["isAdmin"] == "true" {
+		caller, err := env.currentUser(ctx)
+		if err != nil || !caller.IsAdmin {
+			forbidden(ctx)
+			return
+		}
+	}
 
 	user := models.User{
 		Username: data["username"],
 		Email: data["email"],
 		Password: []byte(data["password"]),
+		IsAdmin: data["isAdmin"] == "true",
 	}
 
 	errs := user.ValidateUser()
@@ -135,6 +131,10 @@ func (env Env) CreateUser(ctx *fasthttp.RequestCtx) {
 	env.Log.WithFields(logrus.Fields{"event": "Create user", "status": "successful"}).Info(fmt.Sprintf(`A user was created with Id = '%v' and Email = '%v'`, user.Id, user.Email))
 }
 
+//EditUser updates a user. Only the user itself or an admin may edit it; IsAdmin can only be
+//changed by an admin, regardless of who else is allowed to edit the rest of the fields.
+//Changing the password or regenerating the API key additionally requires a valid reauth_token
+//obtained from Reauthenticate, carried in the X-Reauth-Token header
 func (env Env) EditUser(ctx *fasthttp.RequestCtx) {
 	var data map[string] string
 	var user models.User
@@ -142,8 +142,34 @@ func (env Env) EditUser(ctx *fasthttp.RequestCtx) {
 	id := ctx.UserValue("id")
 	ctx.SetContentType("application/json")
 
+	caller, err := env.currentUser(ctx)
+	if err != nil || (caller.Id != fmt.Sprintf("%v", id) && !caller.IsAdmin) {
+		forbidden(ctx)
+		return
+	}
+
 	json.Unmarshal(ctx.Request.Body(), &data)
 
+	if data["password"] != "" || data["apikey"] == "true" {
+		//reauth tokens are bound to whoever proved their password to Reauthenticate, i.e. the
+		//caller, not the target user - this also covers an admin resetting someone else's
+		//credentials, which can never produce a token owned by the target
+		reauthToken := string(ctx.Request.Header.Peek(ReauthHeader))
+		if err := env.consumeReauthToken(ctx, caller.Id, reauthToken); err != nil {
+			ctx.Response.Header.SetStatusCode(403)
+			fmt.Fprint(ctx, `{"error": "Reauthentication required"}`)
+			return
+		}
+	}
+
+	if isAdmin := data["isAdmin"]; isAdmin != "" {
+		if !caller.IsAdmin {
+			forbidden(ctx)
+			return
+		}
+		changes["IsAdmin"] = isAdmin == "true"
+	}
+
 	if username := data["username"]; username != "" {
 		if err := models.ValidateUsername(username); err != nil {
 			ctx.Response.Header.SetStatusCode(400)
@@ -196,7 +222,7 @@ func (env Env) EditUser(ctx *fasthttp.RequestCtx) {
 		return
 	}
 
-	err := env.Db.Model(&user).Updates(changes).Error
+	err = env.Db.Model(&user).Updates(changes).Error
 
 	if err != nil {
 		ctx.Response.Header.SetStatusCode(500)
@@ -208,8 +234,17 @@ func (env Env) EditUser(ctx *fasthttp.RequestCtx) {
 	json.NewEncoder(ctx).Encode(&user)
 }
 
+//DeleteUser deletes a user. Only the user itself or an admin may delete it
 func (env Env) DeleteUser(ctx *fasthttp.RequestCtx) {
 	id := ctx.UserValue("id")
+	ctx.SetContentType("application/json")
+
+	caller, err := env.currentUser(ctx)
+	if err != nil || (caller.Id != fmt.Sprintf("%v", id) && !caller.IsAdmin) {
+		forbidden(ctx)
+		return
+	}
+
 	result := env.Db.Delete(models.User{}, "id = ?", id)
 	if err := result.Error; err != nil {
 		ctx.Response.Header.SetStatusCode(500)
@@ -0,0 +1,20 @@
+package oauth
+
+import "golang.org/x/oauth2/microsoft"
+
+//NewAzureADProvider returns an OAuthProvider backed by Azure AD's OIDC endpoints for the
+//given tenant (use "common" to accept any Microsoft account or organizational directory)
+func NewAzureADProvider(tenant string) OAuthProvider {
+	return newGenericProvider(
+		microsoft.AzureADEndpoint(tenant),
+		"https://graph.microsoft.com/oidc/userinfo",
+		[]string{"openid", "email", "profile"},
+		func(raw map[string]interface{}) UserInfo {
+			return UserInfo{
+				Subject:  stringField(raw, "sub"),
+				Email:    stringField(raw, "email"),
+				Username: stringField(raw, "name"),
+			}
+		},
+	)
+}
@@ -0,0 +1,65 @@
+package oauth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/oauth2"
+)
+
+//discoveryDocument is the subset of a `.well-known/openid-configuration` response this package
+//needs in order to talk to an arbitrary OIDC-compliant identity provider
+type discoveryDocument struct {
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+}
+
+//genericOIDCProvider is an OAuthProvider for any identity provider that exposes standard OIDC
+//discovery metadata at `{Issuer}/.well-known/openid-configuration`, such as Okta, Keycloak or
+//Auth0
+type genericOIDCProvider struct {
+	*genericProvider
+}
+
+//NewGenericOIDCProvider returns an OAuthProvider which discovers its endpoints from the
+//provider's issuer at InitProvider time
+func NewGenericOIDCProvider() OAuthProvider {
+	return &genericOIDCProvider{genericProvider: &genericProvider{}}
+}
+
+func (p *genericOIDCProvider) InitProvider(cfg ProviderConfig) error {
+	if cfg.Issuer == "" {
+		return fmt.Errorf("oauth: a generic OIDC provider requires an Issuer")
+	}
+
+	resp, err := http.Get(cfg.Issuer + "/.well-known/openid-configuration")
+	if err != nil {
+		return fmt.Errorf("discovering oidc provider: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var doc discoveryDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return fmt.Errorf("decoding oidc discovery document: %w", err)
+	}
+
+	p.userInfoURL = doc.UserinfoEndpoint
+	p.normalize = func(raw map[string]interface{}) UserInfo {
+		return UserInfo{
+			Subject:  stringField(raw, "sub"),
+			Email:    stringField(raw, "email"),
+			Username: stringField(raw, "preferred_username"),
+		}
+	}
+	p.oauth2Cfg = oauth2.Config{
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  doc.AuthorizationEndpoint,
+			TokenURL: doc.TokenEndpoint,
+		},
+		Scopes: []string{"openid", "email", "profile"},
+	}
+
+	return p.genericProvider.InitProvider(cfg)
+}
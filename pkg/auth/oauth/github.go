@@ -0,0 +1,29 @@
+package oauth
+
+import (
+	"strconv"
+
+	"golang.org/x/oauth2/endpoints"
+)
+
+//NewGitHubProvider returns an OAuthProvider backed by GitHub's OAuth2 endpoints.
+//GitHub doesn't speak OIDC, so the subject is derived from the numeric user id returned by
+//the REST userinfo endpoint instead of a "sub" claim
+func NewGitHubProvider() OAuthProvider {
+	return newGenericProvider(
+		endpoints.GitHub,
+		"https://api.github.com/user",
+		[]string{"read:user", "user:email"},
+		func(raw map[string]interface{}) UserInfo {
+			var subject string
+			if id, ok := raw["id"].(float64); ok {
+				subject = strconv.FormatInt(int64(id), 10)
+			}
+			return UserInfo{
+				Subject:  subject,
+				Email:    stringField(raw, "email"),
+				Username: stringField(raw, "login"),
+			}
+		},
+	)
+}
@@ -0,0 +1,26 @@
+package oauth
+
+import "golang.org/x/oauth2/google"
+
+//NewGoogleProvider returns an OAuthProvider backed by Google's OAuth2/OIDC endpoints
+func NewGoogleProvider() OAuthProvider {
+	return newGenericProvider(
+		google.Endpoint,
+		"https://www.googleapis.com/oauth2/v3/userinfo",
+		[]string{"openid", "email", "profile"},
+		func(raw map[string]interface{}) UserInfo {
+			return UserInfo{
+				Subject:  stringField(raw, "sub"),
+				Email:    stringField(raw, "email"),
+				Username: stringField(raw, "name"),
+			}
+		},
+	)
+}
+
+func stringField(raw map[string]interface{}, key string) string {
+	if v, ok := raw[key].(string); ok {
+		return v
+	}
+	return ""
+}
@@ -0,0 +1,141 @@
+//Package oauth provides OAuth2/OIDC single sign-on support for linksh, letting users
+//authenticate through an external identity provider instead of (or in addition to)
+//the username/password flow.
+package oauth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"time"
+)
+
+//ErrUnknownProvider is returned when a provider name doesn't match any registered provider
+var ErrUnknownProvider = errors.New("unknown oauth provider")
+
+//ErrInvalidState is returned when the state param received on the callback doesn't match
+//a previously signed, non-expired state
+var ErrInvalidState = errors.New("invalid or expired oauth state")
+
+//UserInfo is the normalized set of claims every provider must be able to produce after a
+//successful callback, regardless of how the upstream identity provider shapes its response
+type UserInfo struct {
+	//Subject is the provider-scoped, stable identifier for the user (e.g. Google's "sub")
+	Subject  string
+	Email    string
+	Username string
+}
+
+//ProviderConfig holds the credentials, endpoints and state-signing settings a provider needs
+type ProviderConfig struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	//Issuer is only required by generic OIDC providers, it's used to discover the
+	//authorization/token/userinfo endpoints
+	Issuer string
+	//StateKey signs the state param so HandleCallback can detect tampering
+	StateKey []byte
+	//StateTTL is how long a state param issued by HandleLogin stays valid
+	StateTTL time.Duration
+}
+
+//OAuthProvider is implemented by every identity provider linksh can authenticate against
+type OAuthProvider interface {
+	//InitProvider configures the provider with its client credentials, endpoints and
+	//state-signing settings. It must be called once before HandleLogin/HandleCallback are used
+	InitProvider(cfg ProviderConfig) error
+	//HandleLogin returns the URL the caller should be redirected to in order to start the
+	//login flow at the provider, together with a signed state param the caller must round-trip
+	//back to HandleCallback (e.g. via a short-lived cookie)
+	HandleLogin() (redirectURL string, state string, err error)
+	//HandleCallback verifies the state, exchanges the authorization code for a token and
+	//fetches the user's profile from the provider
+	HandleCallback(code string, state string) (UserInfo, error)
+}
+
+//Registry keeps track of the providers enabled through configuration, keyed by the name
+//used in the `/auth/oauth/{provider}/*` routes (e.g. "google", "github", "azuread")
+type Registry struct {
+	providers map[string]OAuthProvider
+}
+
+//NewRegistry returns an empty provider Registry
+func NewRegistry() *Registry {
+	return &Registry{providers: make(map[string]OAuthProvider)}
+}
+
+//Register adds a provider to the registry under the given name, overwriting any provider
+//previously registered with that name
+func (r *Registry) Register(name string, provider OAuthProvider) {
+	r.providers[name] = provider
+}
+
+//Get returns the provider registered under name, or ErrUnknownProvider if none matches
+func (r *Registry) Get(name string) (OAuthProvider, error) {
+	provider, ok := r.providers[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return provider, nil
+}
+
+//SignState produces a base64 state token binding a random nonce to the current time, signed
+//with key so it can later be checked for tampering and expiry with VerifyState
+func SignState(key []byte) (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	payload := appendInt64(nonce, time.Now().Unix())
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	signature := mac.Sum(nil)
+
+	return base64.RawURLEncoding.EncodeToString(append(payload, signature...)), nil
+}
+
+//VerifyState checks the signature and expiry of a state token previously produced by SignState
+func VerifyState(key []byte, state string, ttl time.Duration) error {
+	raw, err := base64.RawURLEncoding.DecodeString(state)
+	if err != nil || len(raw) < 16+8+sha256.Size {
+		return ErrInvalidState
+	}
+
+	payload, signature := raw[:16+8], raw[16+8:]
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	expectedSignature := mac.Sum(nil)
+	if !hmac.Equal(signature, expectedSignature) {
+		return ErrInvalidState
+	}
+
+	issuedAt := readInt64(payload[16:])
+	if time.Since(time.Unix(issuedAt, 0)) > ttl {
+		return ErrInvalidState
+	}
+
+	return nil
+}
+
+func appendInt64(b []byte, v int64) []byte {
+	out := make([]byte, len(b)+8)
+	copy(out, b)
+	for i := 0; i < 8; i++ {
+		out[len(b)+i] = byte(v >> (8 * i))
+	}
+	return out
+}
+
+func readInt64(b []byte) int64 {
+	var v int64
+	for i := 0; i < 8; i++ {
+		v |= int64(b[i]) << (8 * i)
+	}
+	return v
+}
@@ -0,0 +1,84 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"golang.org/x/oauth2"
+)
+
+//genericProvider implements OAuthProvider on top of golang.org/x/oauth2. The Google, GitHub and
+//Azure AD providers are built from it through newGenericProvider and genericOIDCProvider embeds
+//it; each only needs to supply endpoints and a way to normalize the userinfo response into a
+//UserInfo value
+type genericProvider struct {
+	cfg         ProviderConfig
+	oauth2Cfg   oauth2.Config
+	userInfoURL string
+	normalize   func(raw map[string]interface{}) UserInfo
+}
+
+func newGenericProvider(endpoint oauth2.Endpoint, userInfoURL string, scopes []string, normalize func(map[string]interface{}) UserInfo) *genericProvider {
+	return &genericProvider{
+		userInfoURL: userInfoURL,
+		normalize:   normalize,
+		oauth2Cfg: oauth2.Config{
+			Endpoint: endpoint,
+			Scopes:   scopes,
+		},
+	}
+}
+
+func (p *genericProvider) InitProvider(cfg ProviderConfig) error {
+	p.cfg = cfg
+	p.oauth2Cfg.ClientID = cfg.ClientID
+	p.oauth2Cfg.ClientSecret = cfg.ClientSecret
+	p.oauth2Cfg.RedirectURL = cfg.RedirectURL
+	return nil
+}
+
+func (p *genericProvider) HandleLogin() (redirectURL string, state string, err error) {
+	state, err = SignState(p.cfg.StateKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	return p.oauth2Cfg.AuthCodeURL(state), state, nil
+}
+
+func (p *genericProvider) HandleCallback(code string, state string) (UserInfo, error) {
+	if err := VerifyState(p.cfg.StateKey, state, p.cfg.StateTTL); err != nil {
+		return UserInfo{}, err
+	}
+
+	token, err := p.oauth2Cfg.Exchange(context.Background(), code)
+	if err != nil {
+		return UserInfo{}, fmt.Errorf("exchanging oauth code: %w", err)
+	}
+
+	client := p.oauth2Cfg.Client(context.Background(), token)
+	resp, err := client.Get(p.userInfoURL)
+	if err != nil {
+		return UserInfo{}, fmt.Errorf("fetching userinfo: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return UserInfo{}, fmt.Errorf("fetching userinfo: unexpected status %v", resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return UserInfo{}, fmt.Errorf("reading userinfo response: %w", err)
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return UserInfo{}, fmt.Errorf("decoding userinfo response: %w", err)
+	}
+
+	return p.normalize(raw), nil
+}
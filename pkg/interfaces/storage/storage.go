@@ -0,0 +1,32 @@
+//Package storage defines the contract a storage backend must implement to be used by
+//repositories.UserRepository
+package storage
+
+import (
+	"github.com/nethruster/linksh/pkg/interfaces/user_repository"
+	"github.com/nethruster/linksh/pkg/models"
+)
+
+//NotFoundError is returned by an IStorage implementation when the requested entity doesn't exist
+type NotFoundError struct {
+	//Entity is the name of the kind of entity that wasn't found (e.g. "user")
+	Entity string
+	//ID is the identifier that was looked up
+	ID string
+}
+
+func (e *NotFoundError) Error() string {
+	return "not found: " + e.Entity + " " + e.ID
+}
+
+//IStorage is implemented by every storage backend linksh can persist users to
+type IStorage interface {
+	GetUser(id string) (models.User, error)
+	GetUserByName(name string) (models.User, error)
+	SaveUser(user models.User) error
+	//ListUsers returns the users matching limit/offset, along with the total number of users
+	//ignoring limit/offset, so that callers can build pagination headers without a second query
+	ListUsers(limit, offset uint) (users []models.User, total uint, err error)
+	UpdateUser(payload user_repository.UpdatePayload) error
+	DeleteUser(id string) error
+}
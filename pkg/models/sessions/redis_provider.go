@@ -0,0 +1,259 @@
+package sessions
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	sessionKeyPrefix = "session:"
+	ownerIndexPrefix = "user_sessions:"
+	pubsubChannel    = "linksh_sessions"
+
+	eventSessionDeleted = "session_deleted"
+	eventSessionUpdated = "session_updated"
+)
+
+//SessionEvent is published on the pub/sub channel whenever a session is added, updated or
+//deleted so that peer nodes can invalidate any in-memory cache built on top of a SessionManager
+type SessionEvent struct {
+	Type      string
+	SessionID string
+}
+
+//RedisSessionProvider is a SessionProvider backed by Redis (or a valkey-compatible server) so
+//multiple linksh instances can share session state instead of keeping it only in memory
+type RedisSessionProvider struct {
+	client *redis.Client
+}
+
+//NewRedisSessionProvider returns a RedisSessionProvider which stores sessions through client
+func NewRedisSessionProvider(client *redis.Client) *RedisSessionProvider {
+	return &RedisSessionProvider{client: client}
+}
+
+func sessionKey(id string) string {
+	return sessionKeyPrefix + id
+}
+
+func ownerIndexKey(ownerID string) string {
+	return ownerIndexPrefix + ownerID
+}
+
+//Add stores session as a hash with a TTL derived from its ExpiresOn and indexes it under its
+//owner, then announces the change to peer nodes
+func (p *RedisSessionProvider) Add(ctx context.Context, session Session) error {
+	key := sessionKey(session.ID)
+	fields := map[string]interface{}{
+		"OwnerID":   session.OwnerID,
+		"ExpiresOn": session.ExpiresOn,
+		"CreatedAt": session.CreatedAt,
+	}
+
+	//an already-expired session must never be written without a bounded TTL: HSet alone would
+	//otherwise leave the hash with no expiration at all, making it live in Redis forever
+	ttl := time.Until(time.Unix(session.ExpiresOn, 0))
+	if ttl <= 0 {
+		p.client.Del(ctx, key)
+		p.client.SRem(ctx, ownerIndexKey(session.OwnerID), session.ID)
+		return nil
+	}
+
+	if err := p.client.HSet(ctx, key, fields).Err(); err != nil {
+		return err
+	}
+
+	if err := p.client.Expire(ctx, key, ttl).Err(); err != nil {
+		return err
+	}
+
+	if err := p.client.SAdd(ctx, ownerIndexKey(session.OwnerID), session.ID).Err(); err != nil {
+		return err
+	}
+
+	return p.publish(ctx, eventSessionUpdated, session.ID)
+}
+
+//Get returns the session stored under id, or an error if it doesn't exist or has expired
+func (p *RedisSessionProvider) Get(ctx context.Context, id string) (Session, error) {
+	values, err := p.client.HGetAll(ctx, sessionKey(id)).Result()
+	if err != nil {
+		return Session{}, err
+	}
+	if len(values) == 0 {
+		return Session{}, fmt.Errorf("session not found")
+	}
+
+	session, err := parseSessionHash(id, values)
+	if err != nil {
+		return Session{}, err
+	}
+
+	//defense in depth: the key's own TTL is what actually expires a session, but this catches
+	//the narrow race between HSet and Expire in Add/Update without relying on it
+	if time.Now().Unix() >= session.ExpiresOn {
+		return Session{}, fmt.Errorf("session not found")
+	}
+
+	return session, nil
+}
+
+//GetByOwnerID returns every non-expired session belonging to ownerID.
+//Any id left behind in the owner index by a session that already expired through Redis' own
+//TTL is pruned as it's found
+func (p *RedisSessionProvider) GetByOwnerID(ctx context.Context, ownerID string) (map[string]Session, error) {
+	indexKey := ownerIndexKey(ownerID)
+	ids, err := p.client.SMembers(ctx, indexKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make(map[string]Session, len(ids))
+	for _, id := range ids {
+		session, err := p.Get(ctx, id)
+		if err != nil {
+			p.client.SRem(ctx, indexKey, id)
+			continue
+		}
+		sessions[id] = session
+	}
+
+	return sessions, nil
+}
+
+//Update replaces the stored session, refreshing its TTL and owner index entry
+func (p *RedisSessionProvider) Update(ctx context.Context, session Session) error {
+	return p.Add(ctx, session)
+}
+
+//Delete removes the session with the given id from storage and its owner index, then
+//announces the deletion to peer nodes
+func (p *RedisSessionProvider) Delete(ctx context.Context, id string) error {
+	if session, err := p.Get(ctx, id); err == nil {
+		p.client.SRem(ctx, ownerIndexKey(session.OwnerID), id)
+	}
+
+	if err := p.client.Del(ctx, sessionKey(id)).Err(); err != nil {
+		return err
+	}
+
+	return p.publish(ctx, eventSessionDeleted, id)
+}
+
+//GC trims owner index entries whose session already expired through Redis' own key TTL.
+//Expiration itself is handled by Redis, so this only needs to walk the owner indexes, which it
+//does with SCAN instead of KEYS so it doesn't block a shared Redis server with an O(N) sweep
+//of the whole keyspace
+func (p *RedisSessionProvider) GC(ctx context.Context) error {
+	var cursor uint64
+	for {
+		ownerKeys, nextCursor, err := p.client.Scan(ctx, cursor, ownerIndexPrefix+"*", 100).Result()
+		if err != nil {
+			return err
+		}
+
+		for _, ownerKey := range ownerKeys {
+			ids, err := p.client.SMembers(ctx, ownerKey).Result()
+			if err != nil {
+				return err
+			}
+
+			for _, id := range ids {
+				exists, err := p.client.Exists(ctx, sessionKey(id)).Result()
+				if err != nil {
+					return err
+				}
+				if exists == 0 {
+					p.client.SRem(ctx, ownerKey, id)
+				}
+			}
+		}
+
+		cursor = nextCursor
+		if cursor == 0 {
+			break
+		}
+	}
+
+	return nil
+}
+
+//Close releases the underlying Redis client's connections. SessionManager.Shutdown calls this
+//automatically if the configured provider implements io.Closer
+func (p *RedisSessionProvider) Close() error {
+	return p.client.Close()
+}
+
+//Subscribe returns a channel of SessionEvent published by any linksh instance sharing this
+//Redis server, letting an in-memory cache built on top of a SessionManager invalidate its
+//entries. The channel is closed once ctx is done
+func (p *RedisSessionProvider) Subscribe(ctx context.Context) (<-chan SessionEvent, error) {
+	pubsub := p.client.Subscribe(ctx, pubsubChannel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return nil, err
+	}
+
+	events := make(chan SessionEvent)
+	go func() {
+		defer close(events)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				event, err := parseSessionEvent(msg.Payload)
+				if err != nil {
+					continue
+				}
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+func (p *RedisSessionProvider) publish(ctx context.Context, eventType, sessionID string) error {
+	return p.client.Publish(ctx, pubsubChannel, eventType+":"+sessionID).Err()
+}
+
+func parseSessionEvent(payload string) (SessionEvent, error) {
+	for i := 0; i < len(payload); i++ {
+		if payload[i] == ':' {
+			return SessionEvent{Type: payload[:i], SessionID: payload[i+1:]}, nil
+		}
+	}
+	return SessionEvent{}, fmt.Errorf("malformed session event payload %q", payload)
+}
+
+func parseSessionHash(id string, values map[string]string) (Session, error) {
+	expiresOn, err := strconv.ParseInt(values["ExpiresOn"], 10, 64)
+	if err != nil {
+		return Session{}, fmt.Errorf("parsing ExpiresOn: %w", err)
+	}
+	createdAt, err := strconv.ParseInt(values["CreatedAt"], 10, 64)
+	if err != nil {
+		return Session{}, fmt.Errorf("parsing CreatedAt: %w", err)
+	}
+
+	return Session{
+		ID:        id,
+		OwnerID:   values["OwnerID"],
+		ExpiresOn: expiresOn,
+		CreatedAt: createdAt,
+	}, nil
+}
@@ -1,7 +1,10 @@
 package sessions
 
 import (
-    "errors"
+	"context"
+	"errors"
+	"io"
+	"sync"
 	"time"
 )
 
@@ -15,8 +18,10 @@ type Session struct {
 
 //SessionManager is helps to manage the session stored in the provider
 type SessionManager struct {
-    provider SessionProvider
-    autoGCShouldBeRunning bool
+	provider SessionProvider
+
+	gcCancel context.CancelFunc
+	gcWg     sync.WaitGroup
 }
 
 //NewSessionManager Returns a new SessionManager with the selected provider
@@ -26,69 +31,125 @@ func NewSessionManager(provider SessionProvider, providerArguments ...interface{
 }
 
 //Add a session to the provider's storage
-func (manager *SessionManager) Add(session Session) error {
-   return manager.provider.Add(session)
+func (manager *SessionManager) Add(ctx context.Context, session Session) error {
+	return manager.provider.Add(ctx, session)
 }
 
 //Get returns the requested session from the provider's storage, if not found it returns an error
-func (manager *SessionManager) Get(id string) (Session, error) {
-    return manager.provider.Get(id)
+func (manager *SessionManager) Get(ctx context.Context, id string) (Session, error) {
+	return manager.provider.Get(ctx, id)
 }
 
 //GetByOwnerID Returns the sessions which belongs to the selected user
-func (manager *SessionManager) GetByOwnerID(ownerID string) (map[string]Session, error) {
-    return manager.provider.GetByOwnerID(ownerID)
+func (manager *SessionManager) GetByOwnerID(ctx context.Context, ownerID string) (map[string]Session, error) {
+	return manager.provider.GetByOwnerID(ctx, ownerID)
 }
 
 //Update a session
-func (manager *SessionManager) Update(session Session) error {
-    return manager.provider.Update(session)
+func (manager *SessionManager) Update(ctx context.Context, session Session) error {
+	return manager.provider.Update(ctx, session)
 }
 
 //Delete the session with selected id
-func (manager *SessionManager) Delete(id string) error {
-    return manager.provider.Delete(id)
+func (manager *SessionManager) Delete(ctx context.Context, id string) error {
+	return manager.provider.Delete(ctx, id)
 }
 
 //GC deletes expired entries from the provider's storage
-func (manager *SessionManager) GC() error {
-    return manager.provider.GC()
+func (manager *SessionManager) GC(ctx context.Context) error {
+	return manager.provider.GC(ctx)
 }
 
-//EnableAutoGC start a background job which will run the GC function every time "x" has passed
-// Only a job of autoGC can be running at the same time for each SessionManager instance.
-func (manager *SessionManager) EnableAutoGC(x time.Duration) error {
-    if manager.autoGCShouldBeRunning {
-        return errors.New("The autoGC job is already running")
-    }
-    manager.autoGCShouldBeRunning = true
-    go func() {
-        for manager.autoGCShouldBeRunning {
-            manager.GC()
-            time.Sleep(x)
-        }
-    }()
-
-    return nil
+//EnableAutoGC starts a background job which calls GC every time x has passed, until ctx is
+//cancelled or Shutdown is called.
+//Only a job of autoGC can be running at the same time for each SessionManager instance.
+func (manager *SessionManager) EnableAutoGC(ctx context.Context, x time.Duration) error {
+	if manager.gcCancel != nil {
+		return errors.New("The autoGC job is already running")
+	}
+
+	gcCtx, cancel := context.WithCancel(ctx)
+	manager.gcCancel = cancel
+
+	manager.gcWg.Add(1)
+	go func() {
+		defer manager.gcWg.Done()
+
+		ticker := time.NewTicker(x)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-gcCtx.Done():
+				return
+			case <-ticker.C:
+				manager.GC(gcCtx)
+			}
+		}
+	}()
+
+	return nil
 }
 
-//DisableAutoGC stops the autoGC job.
+//DisableAutoGC stops the autoGC job and waits for it to actually have stopped.
 func (manager *SessionManager) DisableAutoGC() error {
-    if !manager.autoGCShouldBeRunning {
-        return errors.New("The autoGC job was not running")
-    }
-    manager.autoGCShouldBeRunning = false
+	if manager.gcCancel == nil {
+		return errors.New("The autoGC job was not running")
+	}
+
+	manager.gcCancel()
+	manager.gcWg.Wait()
+	manager.gcCancel = nil
 
-    return nil
+	return nil
 }
 
+//Shutdown stops the autoGC job, if any, and releases any resource held by the provider.
+//It should be called by main before the process exits so that backends like the SQL or Redis
+//providers can close their connections cleanly
+func (manager *SessionManager) Shutdown(ctx context.Context) error {
+	if manager.gcCancel != nil {
+		if err := manager.DisableAutoGC(); err != nil {
+			return err
+		}
+	}
+
+	if closer, ok := manager.provider.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+//ErrProviderNotSubscribable is returned by Subscribe when the configured provider doesn't
+//implement SubscribableProvider
+var ErrProviderNotSubscribable = errors.New("the configured SessionProvider doesn't support Subscribe")
+
+//SubscribableProvider is an optional capability a SessionProvider can implement to publish
+//session change events for peer nodes to invalidate their own caches (e.g. RedisSessionProvider
+//over its Redis pub/sub channel). SessionManager checks for it the same way Shutdown checks for
+//io.Closer
+type SubscribableProvider interface {
+	Subscribe(ctx context.Context) (<-chan SessionEvent, error)
+}
+
+//Subscribe returns a channel of SessionEvent published by the provider, if the configured
+//provider implements SubscribableProvider; otherwise it returns ErrProviderNotSubscribable
+func (manager *SessionManager) Subscribe(ctx context.Context) (<-chan SessionEvent, error) {
+	subscribable, ok := manager.provider.(SubscribableProvider)
+	if !ok {
+		return nil, ErrProviderNotSubscribable
+	}
+
+	return subscribable.Subscribe(ctx)
+}
 
 //SessionProvider is the interface for a valid session storage
 type SessionProvider interface {
-	Add(session Session) error
-	Get(id string) (Session, error)
-	GetByOwnerID(ownerID string) (map[string]Session, error)
-	Update(session Session) error
-	Delete(id string) error
-	GC() error
+	Add(ctx context.Context, session Session) error
+	Get(ctx context.Context, id string) (Session, error)
+	GetByOwnerID(ctx context.Context, ownerID string) (map[string]Session, error)
+	Update(ctx context.Context, session Session) error
+	Delete(ctx context.Context, id string) error
+	GC(ctx context.Context) error
 }
@@ -67,9 +67,10 @@ func (ur *UserRepository) Get(id string) (models.User, error) {
 	return ur.Storage.GetUser(id)
 }
 
-//List lits the users
+//List lits the users, along with the total number of users matching the query (ignoring
+//limit/offset), so that callers can produce pagination headers without an extra round-trip
 //If the limit is set to 0, no limit will be established, the same applies to the offset
-func (ur *UserRepository) List(limit, offset uint) ([]models.User, error) {
+func (ur *UserRepository) List(limit, offset uint) (users []models.User, total uint, err error) {
 	return ur.Storage.ListUsers(limit, offset)
 }
 
@@ -126,10 +127,10 @@ func (ur *UserRepository) GetByUser(requesterID, id string) (user models.User, e
 	return ur.Get(id)
 }
 
-//ListByUser lits the users
+//ListByUser lits the users, along with the total number of users matching the query
 //If limit is set to 0, no limit will be established
 //The requester must be an admin to perform this action
-func (ur *UserRepository) ListByUser(requesterID string, limit, offset uint) (users []models.User, err error) {
+func (ur *UserRepository) ListByUser(requesterID string, limit, offset uint) (users []models.User, total uint, err error) {
 	err = checkIfRequesterIsAdmin(ur.Storage, requesterID)
 	if err != nil {
 		return